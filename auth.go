@@ -0,0 +1,111 @@
+package sntp
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+)
+
+// MACAlgo selects the digest algorithm used to authenticate a packet
+// with a symmetric key.
+type MACAlgo int
+
+const (
+	// MACMD5 authenticates using keyed MD5, producing a 16-byte digest.
+	MACMD5 MACAlgo = iota + 1
+	// MACSHA1 authenticates using keyed SHA-1, producing a 20-byte digest.
+	MACSHA1
+	// MACAESCMAC is reserved for future AES-CMAC support and is not
+	// yet implemented.
+	MACAESCMAC
+)
+
+// ErrAuthFailed is returned when a response's MAC trailer is missing or
+// does not match the configured key.
+var ErrAuthFailed = errors.New("sntp: MAC authentication failed")
+
+// errUnsupportedMACAlgo is returned by computeMAC for an algorithm with
+// no implementation yet (currently only MACAESCMAC).
+var errUnsupportedMACAlgo = errors.New("sntp: unsupported MAC algorithm")
+
+// WithKey authenticates requests, and verifies responses, using the
+// NTPv4 symmetric-key scheme: a 4-byte Key Identifier followed by a
+// digest computed over the 48-byte header as digest(key || header).
+func WithKey(id uint32, key []byte, algo MACAlgo) Option {
+	return func(c *config) {
+		c.keyID = id
+		c.key = key
+		c.macAlgo = algo
+	}
+}
+
+// macDigestSize returns the digest length, in bytes, produced by algo.
+func macDigestSize(algo MACAlgo) (int, error) {
+	switch algo {
+	case MACMD5:
+		return md5.Size, nil
+	case MACSHA1:
+		return sha1.Size, nil
+	default:
+		return 0, errUnsupportedMACAlgo
+	}
+}
+
+// computeMAC computes digest(key || header) using algo.
+func computeMAC(algo MACAlgo, key, header []byte) ([]byte, error) {
+	switch algo {
+	case MACMD5:
+		h := md5.New()
+		h.Write(key)
+		h.Write(header)
+		return h.Sum(nil), nil
+	case MACSHA1:
+		h := sha1.New()
+		h.Write(key)
+		h.Write(header)
+		return h.Sum(nil), nil
+	default:
+		return nil, errUnsupportedMACAlgo
+	}
+}
+
+// appendMAC appends a Key Identifier and digest(key || header) trailer
+// to header.
+func appendMAC(header []byte, keyID uint32, key []byte, algo MACAlgo) ([]byte, error) {
+	digest, err := computeMAC(algo, key, header)
+	if err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, 4+len(digest))
+	binary.BigEndian.PutUint32(trailer[:4], keyID)
+	copy(trailer[4:], digest)
+	return append(header, trailer...), nil
+}
+
+// verifyMAC checks that the trailing bytes of full are a valid MAC
+// trailer for header, i.e. full's first packetSize bytes, given the
+// configured key.
+func verifyMAC(full []byte, keyID uint32, key []byte, algo MACAlgo) error {
+	digestLen, err := macDigestSize(algo)
+	if err != nil {
+		return err
+	}
+	macLen := 4 + digestLen
+	if len(full) < packetSize+macLen {
+		return ErrAuthFailed
+	}
+	trailer := full[len(full)-macLen:]
+	gotKeyID := binary.BigEndian.Uint32(trailer[:4])
+	gotDigest := trailer[4:]
+
+	wantDigest, err := computeMAC(algo, key, full[:packetSize])
+	if err != nil {
+		return err
+	}
+	if gotKeyID != keyID || !hmac.Equal(gotDigest, wantDigest) {
+		return ErrAuthFailed
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package sntp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMacDigestSize(t *testing.T) {
+	cases := []struct {
+		algo MACAlgo
+		want int
+	}{
+		{MACMD5, 16},
+		{MACSHA1, 20},
+	}
+	for _, tc := range cases {
+		got, err := macDigestSize(tc.algo)
+		if err != nil {
+			t.Fatalf("macDigestSize(%v): %v", tc.algo, err)
+		}
+		if got != tc.want {
+			t.Errorf("macDigestSize(%v) = %d, want %d", tc.algo, got, tc.want)
+		}
+	}
+
+	if _, err := macDigestSize(MACAESCMAC); err == nil {
+		t.Errorf("macDigestSize(MACAESCMAC) = nil error, want errUnsupportedMACAlgo (not yet implemented)")
+	}
+}
+
+func TestComputeMACIsDeterministicAndKeyed(t *testing.T) {
+	header := bytes.Repeat([]byte{0xAB}, packetSize)
+
+	d1, err := computeMAC(MACMD5, []byte("key1"), header)
+	if err != nil {
+		t.Fatalf("computeMAC: %v", err)
+	}
+	d2, err := computeMAC(MACMD5, []byte("key1"), header)
+	if err != nil {
+		t.Fatalf("computeMAC: %v", err)
+	}
+	if !bytes.Equal(d1, d2) {
+		t.Errorf("computeMAC is not deterministic for the same key and header")
+	}
+
+	d3, err := computeMAC(MACMD5, []byte("key2"), header)
+	if err != nil {
+		t.Fatalf("computeMAC: %v", err)
+	}
+	if bytes.Equal(d1, d3) {
+		t.Errorf("computeMAC produced the same digest for different keys")
+	}
+}
+
+func TestAppendAndVerifyMACRoundTrip(t *testing.T) {
+	header := bytes.Repeat([]byte{0x11}, packetSize)
+	key := []byte("shared-secret")
+
+	full, err := appendMAC(header, 7, key, MACSHA1)
+	if err != nil {
+		t.Fatalf("appendMAC: %v", err)
+	}
+	wantLen := packetSize + 4 + 20 // key ID + SHA-1 digest
+	if len(full) != wantLen {
+		t.Fatalf("appendMAC produced %d bytes, want %d", len(full), wantLen)
+	}
+
+	if err := verifyMAC(full, 7, key, MACSHA1); err != nil {
+		t.Fatalf("verifyMAC(genuine trailer) = %v, want nil", err)
+	}
+}
+
+func TestVerifyMACRejectsTamperedInput(t *testing.T) {
+	header := bytes.Repeat([]byte{0x22}, packetSize)
+	key := []byte("shared-secret")
+
+	full, err := appendMAC(header, 7, key, MACMD5)
+	if err != nil {
+		t.Fatalf("appendMAC: %v", err)
+	}
+
+	t.Run("wrong key ID", func(t *testing.T) {
+		if err := verifyMAC(full, 8, key, MACMD5); err != ErrAuthFailed {
+			t.Errorf("verifyMAC(wrong key ID) = %v, want ErrAuthFailed", err)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		if err := verifyMAC(full, 7, []byte("wrong-secret"), MACMD5); err != ErrAuthFailed {
+			t.Errorf("verifyMAC(wrong key) = %v, want ErrAuthFailed", err)
+		}
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		tampered := append([]byte(nil), full...)
+		tampered[0] ^= 0xFF
+		if err := verifyMAC(tampered, 7, key, MACMD5); err != ErrAuthFailed {
+			t.Errorf("verifyMAC(tampered header) = %v, want ErrAuthFailed", err)
+		}
+	})
+
+	t.Run("truncated trailer", func(t *testing.T) {
+		if err := verifyMAC(full[:packetSize+4], 7, key, MACMD5); err != ErrAuthFailed {
+			t.Errorf("verifyMAC(truncated trailer) = %v, want ErrAuthFailed", err)
+		}
+	})
+}
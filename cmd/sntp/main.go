@@ -0,0 +1,50 @@
+// This program implements a trivial NTP client over UDP. It can also
+// act as a simple SNTP server with -serve.
+//
+// Usage:
+// sntp -e <host endpoint as addr:port>
+// sntp -serve <listen addr:port>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/dranidis/sntp"
+)
+
+func main() {
+	var host, serve string
+	flag.StringVar(&host, "e", "us.pool.ntp.org:123", "NTP host")
+	flag.StringVar(&serve, "serve", "", "listen address to serve SNTP on, e.g. :123")
+	flag.Parse()
+
+	if serve != "" {
+		runServer(serve)
+		return
+	}
+
+	rsp, err := sntp.Query(host)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(rsp.Time)
+	fmt.Println("clock offset:", rsp.ClockOffset)
+	fmt.Println("round-trip delay:", rsp.RTT)
+}
+
+func runServer(addr string) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	log.Printf("serving SNTP on %s", addr)
+	if err := sntp.Serve(conn, sntp.ServerOptions{}); err != nil {
+		log.Fatal(err)
+	}
+}
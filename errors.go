@@ -0,0 +1,58 @@
+package sntp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by Query and QueryWithSamples when a server
+// response fails RFC 5905 validation. Use errors.Is to test for them.
+var (
+	// ErrKissOfDeath is returned when the server sends a kiss-o'-death
+	// response (Stratum == 0). Use errors.As to recover the KissCode.
+	ErrKissOfDeath = errors.New("sntp: kiss-of-death response")
+	// ErrInvalidStratum is returned when the server reports an
+	// unsynchronized stratum (>= 16).
+	ErrInvalidStratum = errors.New("sntp: invalid stratum")
+	// ErrNotSynchronized is returned when the server's leap indicator
+	// reports that its clock is not synchronized.
+	ErrNotSynchronized = errors.New("sntp: server clock not synchronized")
+	// ErrSpoofedResponse is returned when the response's origin
+	// timestamp does not match the randomized timestamp we sent,
+	// indicating the packet was not a genuine reply to our request.
+	ErrSpoofedResponse = errors.New("sntp: origin timestamp mismatch, possible spoofed response")
+	// ErrInvalidMode is returned when the response's mode is not
+	// server(4).
+	ErrInvalidMode = errors.New("sntp: unexpected mode in response")
+	// ErrVersionMismatch is returned when the response's version
+	// differs from the request's.
+	ErrVersionMismatch = errors.New("sntp: version mismatch in response")
+	// ErrZeroTransmitTimestamp is returned when the response's
+	// transmit timestamp is zero.
+	ErrZeroTransmitTimestamp = errors.New("sntp: server transmit timestamp is zero")
+)
+
+// KissCode identifies the reason a server sent a kiss-o'-death response,
+// decoded from the 4-byte ASCII ReferenceID (e.g. "RATE", "DENY", "RSTR").
+type KissCode string
+
+// KissOfDeathError reports a kiss-o'-death response from the server. It
+// unwraps to ErrKissOfDeath.
+type KissOfDeathError struct {
+	Code KissCode
+}
+
+func (e *KissOfDeathError) Error() string {
+	return fmt.Sprintf("sntp: kiss-of-death: %s", e.Code)
+}
+
+func (e *KissOfDeathError) Unwrap() error {
+	return ErrKissOfDeath
+}
+
+// decodeKissCode decodes a kiss-o'-death ReferenceID into its ASCII
+// KissCode.
+func decodeKissCode(id uint32) KissCode {
+	b := []byte{byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id)}
+	return KissCode(b)
+}
@@ -0,0 +1,49 @@
+package sntp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ExtensionField is one NTPv4 extension field, as used by NTS and
+// autokey. Fields are 4-byte-aligned TLV records that may appear
+// between the 48-byte header and an optional MAC trailer.
+type ExtensionField struct {
+	Type  uint16
+	Value []byte
+}
+
+// ParseExtensionFields walks the 4-byte-aligned TLV extension records
+// in b, stopping when b is exhausted. It does not attempt to
+// distinguish extension fields from a MAC trailer; callers must slice
+// off any trailing MAC before calling this.
+func ParseExtensionFields(b []byte) ([]ExtensionField, error) {
+	var fields []ExtensionField
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("sntp: truncated extension field")
+		}
+		typ := binary.BigEndian.Uint16(b[0:2])
+		length := binary.BigEndian.Uint16(b[2:4])
+		if length < 4 || length%4 != 0 || int(length) > len(b) {
+			return nil, fmt.Errorf("sntp: invalid extension field length %d", length)
+		}
+		fields = append(fields, ExtensionField{Type: typ, Value: b[4:length]})
+		b = b[length:]
+	}
+	return fields, nil
+}
+
+// EncodeExtensionField serializes f as a 4-byte-aligned TLV record,
+// padding its value with zero bytes as needed.
+func EncodeExtensionField(f ExtensionField) []byte {
+	length := 4 + len(f.Value)
+	if pad := length % 4; pad != 0 {
+		length += 4 - pad
+	}
+	buf := make([]byte, length)
+	binary.BigEndian.PutUint16(buf[0:2], f.Type)
+	binary.BigEndian.PutUint16(buf[2:4], uint16(length))
+	copy(buf[4:], f.Value)
+	return buf
+}
@@ -0,0 +1,75 @@
+package sntp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeParseExtensionFieldRoundTrip(t *testing.T) {
+	cases := []struct {
+		name      string
+		in        ExtensionField
+		wantValue []byte // the value as it round-trips, including any zero padding
+	}{
+		{"already 4-byte aligned value", ExtensionField{Type: 0x0104, Value: []byte{1, 2, 3, 4}}, []byte{1, 2, 3, 4}},
+		{"value needs padding", ExtensionField{Type: 0x0204, Value: []byte{1, 2, 3}}, []byte{1, 2, 3, 0}},
+		{"empty value", ExtensionField{Type: 0x0404, Value: nil}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded := EncodeExtensionField(tc.in)
+			if len(encoded)%4 != 0 {
+				t.Fatalf("EncodeExtensionField produced %d bytes, not 4-byte aligned", len(encoded))
+			}
+
+			got, err := ParseExtensionFields(encoded)
+			if err != nil {
+				t.Fatalf("ParseExtensionFields: %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("ParseExtensionFields returned %d fields, want 1", len(got))
+			}
+			if got[0].Type != tc.in.Type {
+				t.Errorf("Type = %#x, want %#x", got[0].Type, tc.in.Type)
+			}
+			if !bytes.Equal(got[0].Value, tc.wantValue) {
+				t.Errorf("Value = %v, want %v", got[0].Value, tc.wantValue)
+			}
+		})
+	}
+}
+
+func TestParseExtensionFieldsMultiple(t *testing.T) {
+	a := EncodeExtensionField(ExtensionField{Type: 1, Value: []byte{0xAA}})
+	b := EncodeExtensionField(ExtensionField{Type: 2, Value: []byte{0xBB, 0xCC}})
+
+	fields, err := ParseExtensionFields(append(a, b...))
+	if err != nil {
+		t.Fatalf("ParseExtensionFields: %v", err)
+	}
+	if len(fields) != 2 {
+		t.Fatalf("ParseExtensionFields returned %d fields, want 2", len(fields))
+	}
+	if fields[0].Type != 1 || fields[1].Type != 2 {
+		t.Errorf("fields = %+v, want types [1, 2]", fields)
+	}
+}
+
+func TestParseExtensionFieldsRejectsMalformedInput(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []byte
+	}{
+		{"truncated header", []byte{0, 1}},
+		{"length not 4-byte aligned", []byte{0, 1, 0, 5, 0, 0, 0, 0, 0}},
+		{"length shorter than header", []byte{0, 1, 0, 0}},
+		{"length exceeds remaining bytes", []byte{0, 1, 0, 40}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseExtensionFields(tc.in); err == nil {
+				t.Errorf("ParseExtensionFields(%v) = nil error, want error", tc.in)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package sntp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+// packetSize is the fixed size, in bytes, of the NTP header below,
+// excluding any NTPv4 extension fields or MAC trailer.
+const packetSize = 48
+
+const ntpEpochOffset = 2208988800
+
+// NTP packet format (v3 with optional v4 fields removed)
+//
+// 0                   1                   2                   3
+// 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |LI | VN  |Mode |    Stratum     |     Poll      |  Precision   |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |                         Root Delay                            |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |                         Root Dispersion                       |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |                          Reference ID                         |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |                                                               |
+// +                     Reference Timestamp (64)                  +
+// |                                                               |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |                                                               |
+// +                      Origin Timestamp (64)                    +
+// |                                                               |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |                                                               |
+// +                      Receive Timestamp (64)                   +
+// |                                                               |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |                                                               |
+// +                      Transmit Timestamp (64)                  +
+// |                                                               |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+type packet struct {
+	Settings       uint8  // leap yr indicator, ver number, and mode
+	Stratum        uint8  // stratum of local clock
+	Poll           int8   // poll exponent
+	Precision      int8   // precision exponent
+	RootDelay      uint32 // root delay
+	RootDispersion uint32 // root dispersion
+	ReferenceID    uint32 // reference id
+	RefTimeSec     uint32 // reference timestamp sec
+	RefTimeFrac    uint32 // reference timestamp fractional
+	OrigTimeSec    uint32 // origin time secs
+	OrigTimeFrac   uint32 // origin time fractional
+	RxTimeSec      uint32 // receive time secs
+	RxTimeFrac     uint32 // receive time frac
+	TxTimeSec      uint32 // transmit time secs
+	TxTimeFrac     uint32 // transmit time frac
+}
+
+// leapIndicator returns the two-bit leap indicator packed into Settings.
+func (p *packet) leapIndicator() uint8 {
+	return p.Settings >> 6
+}
+
+// version returns the three-bit version number packed into Settings.
+func (p *packet) version() uint8 {
+	return (p.Settings >> 3) & 0x07
+}
+
+// mode returns the three-bit mode packed into Settings.
+func (p *packet) mode() uint8 {
+	return p.Settings & 0x07
+}
+
+// ntpToTime converts NTP era-0 seconds and a fractional-second field into
+// a time.Time.
+//
+// On POSIX-compliant OS, time is expressed using the Unix time epoch (or
+// secs since year 1970). NTP seconds are counted since 1900 and therefore
+// must be corrected with an epoch offset to convert NTP seconds to Unix
+// time by removing 70 yrs of seconds (1970-1900) or 2208988800 seconds.
+func ntpToTime(s, f uint32) time.Time {
+	secs := float64(s) - ntpEpochOffset
+	nanos := (int64(f) * 1e9) >> 32 // convert fractional to nanos
+	return time.Unix(int64(secs), nanos)
+}
+
+// timeToNTP converts a time.Time into NTP era-0 seconds and a
+// fractional-second field.
+func timeToNTP(t time.Time) (sec, frac uint32) {
+	sec = uint32(t.Unix() + ntpEpochOffset)
+	frac = uint32((int64(t.Nanosecond()) << 32) / 1e9)
+	return sec, frac
+}
+
+// shortToDuration converts an NTP short format (16.16 fixed point seconds)
+// value, as used for RootDelay and RootDispersion, into a time.Duration.
+func shortToDuration(v uint32) time.Duration {
+	return time.Duration(float64(v) / 65536.0 * float64(time.Second))
+}
+
+// precisionToDuration converts the packet's Precision exponent (log2
+// seconds) into a time.Duration.
+func precisionToDuration(p int8) time.Duration {
+	if p >= 0 {
+		return time.Duration(uint64(1)<<uint(p)) * time.Second
+	}
+	return time.Second >> uint(-p)
+}
+
+// marshalPacket encodes p's fixed-size header into wire format.
+func marshalPacket(p *packet) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.BigEndian, p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalPacket decodes a fixed-size header from wire format. b must
+// be at least packetSize bytes; any trailing extension fields or MAC
+// must be handled separately.
+func unmarshalPacket(b []byte) (*packet, error) {
+	p := &packet{}
+	if err := binary.Read(bytes.NewReader(b[:packetSize]), binary.BigEndian, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,86 @@
+package sntp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNtpToTimeRoundTrip(t *testing.T) {
+	want := time.Date(2024, 3, 15, 12, 30, 0, 500_000_000, time.UTC)
+	sec, frac := timeToNTP(want)
+	got := ntpToTime(sec, frac)
+
+	if diff := got.Sub(want); diff > time.Millisecond || diff < -time.Millisecond {
+		t.Fatalf("ntpToTime(timeToNTP(%v)) = %v, want within 1ms", want, got)
+	}
+}
+
+func TestShortToDuration(t *testing.T) {
+	cases := []struct {
+		name string
+		in   uint32
+		want time.Duration
+	}{
+		{"zero", 0, 0},
+		{"one second", 1 << 16, time.Second},
+		{"half second", 1 << 15, 500 * time.Millisecond},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shortToDuration(tc.in); got != tc.want {
+				t.Errorf("shortToDuration(%d) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrecisionToDuration(t *testing.T) {
+	cases := []struct {
+		name string
+		in   int8
+		want time.Duration
+	}{
+		{"zero exponent is one second", 0, time.Second},
+		{"positive exponent", 1, 2 * time.Second},
+		{"negative exponent", -1, 500 * time.Millisecond},
+		{"typical system clock precision", -20, time.Second >> 20},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := precisionToDuration(tc.in); got != tc.want {
+				t.Errorf("precisionToDuration(%d) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMarshalUnmarshalPacketRoundTrip(t *testing.T) {
+	want := &packet{
+		Settings:     0x1B,
+		Stratum:      2,
+		Poll:         4,
+		Precision:    -20,
+		RootDelay:    1234,
+		ReferenceID:  referenceIDFromASCII("GPS"),
+		OrigTimeSec:  111,
+		OrigTimeFrac: 222,
+		TxTimeSec:    333,
+		TxTimeFrac:   444,
+	}
+
+	b, err := marshalPacket(want)
+	if err != nil {
+		t.Fatalf("marshalPacket: %v", err)
+	}
+	if len(b) != packetSize {
+		t.Fatalf("marshalPacket produced %d bytes, want %d", len(b), packetSize)
+	}
+
+	got, err := unmarshalPacket(b)
+	if err != nil {
+		t.Fatalf("unmarshalPacket: %v", err)
+	}
+	if *got != *want {
+		t.Errorf("unmarshalPacket(marshalPacket(p)) = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package sntp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAllSamplesFailed is returned by QueryWithSamples when every sample
+// exchange failed.
+var ErrAllSamplesFailed = errors.New("sntp: all samples failed")
+
+// defaultSampleInterval is the gap between successive exchanges in
+// QueryWithSamples.
+const defaultSampleInterval = 100 * time.Millisecond
+
+// WithSampleInterval overrides the gap between successive exchanges in
+// QueryWithSamples. It has no effect on Query.
+func WithSampleInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.sampleInterval = d
+	}
+}
+
+// QueryWithSamples issues n sequential SNTP exchanges against host and
+// returns the response with the lowest round-trip delay, since the
+// sample with minimum delay also minimizes offset error. Each exchange
+// honors its own deadline, so a single slow or unresponsive sample
+// cannot stall the others.
+func QueryWithSamples(host string, n int, opts ...Option) (*Response, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var best *Response
+	var lastErr error
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			time.Sleep(cfg.sampleInterval)
+		}
+		rsp, err := Query(host, opts...)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if best == nil || rsp.RTT < best.RTT {
+			best = rsp
+		}
+	}
+
+	if best == nil {
+		if lastErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAllSamplesFailed, lastErr)
+		}
+		return nil, ErrAllSamplesFailed
+	}
+	return best, nil
+}
@@ -0,0 +1,119 @@
+package sntp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTransport returns a canned sequence of Exchange errors, one per
+// call, so QueryWithSamples's all-failed path can be tested without a
+// real network round-trip.
+type fakeTransport struct {
+	calls int
+	errs  []error
+}
+
+func (f *fakeTransport) Exchange(req []byte, deadline time.Time) ([]byte, time.Time, error) {
+	i := f.calls
+	f.calls++
+	if i >= len(f.errs) {
+		return nil, time.Time{}, errors.New("fakeTransport: no more canned errors")
+	}
+	return nil, time.Time{}, f.errs[i]
+}
+
+func (f *fakeTransport) Close() error { return nil }
+
+// delayedTransport simulates a server with negligible processing time
+// but a controllable network delay, so each sample's RTT (as Query
+// computes it) is approximately the configured delay. Each Exchange
+// also echoes the request's nonce back as OrigTimestamp, as a genuine
+// server would, so the response passes validateResponse.
+type delayedTransport struct {
+	delays []time.Duration
+	calls  int
+}
+
+func (f *delayedTransport) Exchange(req []byte, deadline time.Time) ([]byte, time.Time, error) {
+	i := f.calls
+	f.calls++
+	if i >= len(f.delays) {
+		return nil, time.Time{}, errors.New("delayedTransport: no more canned samples")
+	}
+
+	reqPacket, err := unmarshalPacket(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := time.Now()
+	rxSec, rxFrac := timeToNTP(now)
+	txSec, txFrac := timeToNTP(now)
+	rsp := &packet{
+		Settings:     0<<6 | reqPacket.version()<<3 | serverMode,
+		Stratum:      1,
+		OrigTimeSec:  reqPacket.TxTimeSec,
+		OrigTimeFrac: reqPacket.TxTimeFrac,
+		RxTimeSec:    rxSec,
+		RxTimeFrac:   rxFrac,
+		TxTimeSec:    txSec,
+		TxTimeFrac:   txFrac,
+	}
+	b, err := marshalPacket(rsp)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return b, now.Add(f.delays[i]), nil
+}
+
+func (f *delayedTransport) Close() error { return nil }
+
+func TestQueryWithSamplesReturnsLowestRTT(t *testing.T) {
+	transport := &delayedTransport{delays: []time.Duration{
+		50 * time.Millisecond, // slow
+		5 * time.Millisecond,  // fastest
+		80 * time.Millisecond, // slow
+	}}
+
+	rsp, err := QueryWithSamples("ignored.example.com", 3,
+		WithTransport(transport), WithSampleInterval(0))
+	if err != nil {
+		t.Fatalf("QueryWithSamples: %v", err)
+	}
+	if rsp.RTT <= 0 || rsp.RTT >= 50*time.Millisecond {
+		t.Errorf("RTT = %v, want the fastest sample's RTT (~5ms)", rsp.RTT)
+	}
+}
+
+func TestQueryWithSamplesAllFail(t *testing.T) {
+	wantErr := errors.New("boom")
+	transport := &fakeTransport{errs: []error{wantErr, wantErr}}
+
+	_, err := QueryWithSamples("ignored.example.com", 2,
+		WithTransport(transport), WithSampleInterval(0))
+	if !errors.Is(err, ErrAllSamplesFailed) {
+		t.Fatalf("QueryWithSamples() = %v, want ErrAllSamplesFailed", err)
+	}
+	if !strings.Contains(err.Error(), wantErr.Error()) {
+		t.Errorf("QueryWithSamples() = %v, want it to mention the last sample's error (%v)", err, wantErr)
+	}
+}
+
+func TestQueryWithSamplesHonorsSampleInterval(t *testing.T) {
+	transport := &delayedTransport{delays: []time.Duration{0, 0, 0}}
+
+	interval := 30 * time.Millisecond
+	start := time.Now()
+	if _, err := QueryWithSamples("ignored.example.com", 3,
+		WithTransport(transport), WithSampleInterval(interval)); err != nil {
+		t.Fatalf("QueryWithSamples: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	// Two gaps between three samples; allow generous scheduling slack.
+	want := 2 * interval
+	if elapsed < want {
+		t.Errorf("QueryWithSamples took %v, want at least %v (WithSampleInterval not honored)", elapsed, want)
+	}
+}
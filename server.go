@@ -0,0 +1,123 @@
+package sntp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"time"
+)
+
+// defaultStratum and defaultPrecision describe a reasonably well-behaved
+// local reference clock, used when ServerOptions.Upstream is nil.
+const (
+	defaultStratum   = 1
+	defaultPrecision = -20 // ~1us, typical of a modern system clock
+)
+
+// clientMode is the Mode value a client request must use.
+const clientMode = 3
+
+// minVersion and maxVersion bound the NTP version numbers Serve will
+// respond to; 0 and 5-7 are not defined by any NTP revision.
+const (
+	minVersion = 1
+	maxVersion = 4
+)
+
+// defaultReferenceID is the ASCII reference identifier advertised for
+// the local reference clock, per RFC 5905's convention for stratum 1
+// servers.
+var defaultReferenceID = referenceIDFromASCII("LOCL")
+
+// ServerOptions configures the behavior of Serve.
+type ServerOptions struct {
+	// Upstream, if non-nil, supplies the Stratum, ReferenceID,
+	// Precision, and LeapIndicator advertised to clients, typically the
+	// Response of a prior Query against a real time source. If nil, the
+	// server advertises itself as a stratum-1 local reference clock.
+	Upstream *Response
+}
+
+// resolve returns the Stratum, ReferenceID, Precision, and
+// LeapIndicator this server should advertise.
+func (o ServerOptions) resolve() (stratum uint8, refID uint32, precision int8, leap uint8) {
+	if o.Upstream != nil {
+		return o.Upstream.Stratum, o.Upstream.ReferenceID, durationToPrecision(o.Upstream.Precision), o.Upstream.LeapIndicator
+	}
+	return defaultStratum, defaultReferenceID, defaultPrecision, 0
+}
+
+// referenceIDFromASCII packs up to 4 ASCII characters into a
+// ReferenceID, per RFC 5905.
+func referenceIDFromASCII(s string) uint32 {
+	var b [4]byte
+	copy(b[:], s)
+	return binary.BigEndian.Uint32(b[:])
+}
+
+// durationToPrecision converts a time.Duration back into the log2-seconds
+// exponent used by the packet's Precision field.
+func durationToPrecision(d time.Duration) int8 {
+	if d <= 0 {
+		return defaultPrecision
+	}
+	return int8(math.Round(math.Log2(d.Seconds())))
+}
+
+// Serve reads client requests from conn and replies to each with a
+// server-mode SNTP response, until conn is closed or ReadFrom returns an
+// error. It mirrors the server half of RFC 5905: the client's transmit
+// timestamp is echoed back as the origin timestamp, RxTimestamp is
+// filled on packet arrival, and TxTimestamp is filled immediately before
+// the reply is sent.
+func Serve(conn net.PacketConn, opts ServerOptions) error {
+	stratum, refID, precision, leap := opts.resolve()
+
+	buf := make([]byte, 48)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		if n < 48 {
+			continue // too short to be a valid NTP packet, ignore
+		}
+
+		req := &packet{}
+		if err := binary.Read(bytes.NewReader(buf[:48]), binary.BigEndian, req); err != nil {
+			continue
+		}
+		rxSec, rxFrac := timeToNTP(time.Now())
+
+		if req.mode() != clientMode {
+			continue // only service client requests
+		}
+		if v := req.version(); v < minVersion || v > maxVersion {
+			continue // unsupported version, don't echo it back
+		}
+		if req.leapIndicator() == 3 {
+			continue // client reports an alarm condition, not a real request
+		}
+
+		rsp := &packet{
+			Settings:     leap<<6 | req.version()<<3 | serverMode,
+			Stratum:      stratum,
+			Precision:    precision,
+			ReferenceID:  refID,
+			OrigTimeSec:  req.TxTimeSec,
+			OrigTimeFrac: req.TxTimeFrac,
+			RxTimeSec:    rxSec,
+			RxTimeFrac:   rxFrac,
+		}
+		rsp.TxTimeSec, rsp.TxTimeFrac = timeToNTP(time.Now())
+
+		var out bytes.Buffer
+		if err := binary.Write(&out, binary.BigEndian, rsp); err != nil {
+			continue
+		}
+		if _, err := conn.WriteTo(out.Bytes(), addr); err != nil {
+			continue
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package sntp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// startServer launches Serve against a loopback UDP socket in the
+// background and returns the address clients should send to, along
+// with a cleanup func that closes the socket and waits for Serve to
+// return.
+func startServer(t *testing.T, opts ServerOptions) net.Addr {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Serve(conn, opts)
+	}()
+	t.Cleanup(func() {
+		conn.Close()
+		<-done
+	})
+
+	return conn.LocalAddr()
+}
+
+// sendRequest sends req to addr from a fresh client socket and returns
+// whatever reply arrives before deadline, or nil if none does.
+func sendRequest(t *testing.T, addr net.Addr, req *packet) *packet {
+	t.Helper()
+	conn, err := net.Dial("udp", addr.String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	reqBytes, err := marshalPacket(req)
+	if err != nil {
+		t.Fatalf("marshalPacket: %v", err)
+	}
+	if _, err := conn.Write(reqBytes); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond)); err != nil {
+		t.Fatalf("SetReadDeadline: %v", err)
+	}
+	buf := make([]byte, packetSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil
+	}
+	rsp, err := unmarshalPacket(buf[:n])
+	if err != nil {
+		t.Fatalf("unmarshalPacket: %v", err)
+	}
+	return rsp
+}
+
+func clientRequest() *packet {
+	return &packet{Settings: 0x1B, TxTimeSec: 123, TxTimeFrac: 456}
+}
+
+func TestServeRespondsToValidRequest(t *testing.T) {
+	t.Run("local stratum-1 clock", func(t *testing.T) {
+		addr := startServer(t, ServerOptions{})
+		req := clientRequest()
+		rsp := sendRequest(t, addr, req)
+		if rsp == nil {
+			t.Fatal("got no reply to a valid request")
+		}
+		if rsp.OrigTimeSec != req.TxTimeSec || rsp.OrigTimeFrac != req.TxTimeFrac {
+			t.Errorf("OrigTimestamp = (%d, %d), want (%d, %d)", rsp.OrigTimeSec, rsp.OrigTimeFrac, req.TxTimeSec, req.TxTimeFrac)
+		}
+		if rsp.Stratum != defaultStratum {
+			t.Errorf("Stratum = %d, want %d", rsp.Stratum, defaultStratum)
+		}
+		if rsp.ReferenceID != defaultReferenceID {
+			t.Errorf("ReferenceID = %#x, want %#x", rsp.ReferenceID, defaultReferenceID)
+		}
+		if rsp.Precision != defaultPrecision {
+			t.Errorf("Precision = %d, want %d", rsp.Precision, defaultPrecision)
+		}
+		if rsp.leapIndicator() != 0 {
+			t.Errorf("LeapIndicator = %d, want 0", rsp.leapIndicator())
+		}
+	})
+
+	t.Run("upstream clock", func(t *testing.T) {
+		upstream := &Response{
+			Stratum:       3,
+			ReferenceID:   referenceIDFromASCII("GPS"),
+			Precision:     time.Second >> 18,
+			LeapIndicator: 1,
+		}
+		addr := startServer(t, ServerOptions{Upstream: upstream})
+		req := clientRequest()
+		rsp := sendRequest(t, addr, req)
+		if rsp == nil {
+			t.Fatal("got no reply to a valid request")
+		}
+		if rsp.OrigTimeSec != req.TxTimeSec || rsp.OrigTimeFrac != req.TxTimeFrac {
+			t.Errorf("OrigTimestamp = (%d, %d), want (%d, %d)", rsp.OrigTimeSec, rsp.OrigTimeFrac, req.TxTimeSec, req.TxTimeFrac)
+		}
+		if rsp.Stratum != upstream.Stratum {
+			t.Errorf("Stratum = %d, want %d", rsp.Stratum, upstream.Stratum)
+		}
+		if rsp.ReferenceID != upstream.ReferenceID {
+			t.Errorf("ReferenceID = %#x, want %#x", rsp.ReferenceID, upstream.ReferenceID)
+		}
+		if rsp.Precision != durationToPrecision(upstream.Precision) {
+			t.Errorf("Precision = %d, want %d", rsp.Precision, durationToPrecision(upstream.Precision))
+		}
+		if rsp.leapIndicator() != upstream.LeapIndicator {
+			t.Errorf("LeapIndicator = %d, want %d", rsp.leapIndicator(), upstream.LeapIndicator)
+		}
+	})
+}
+
+func TestServeDropsInvalidRequests(t *testing.T) {
+	addr := startServer(t, ServerOptions{})
+
+	cases := []struct {
+		name string
+		req  *packet
+	}{
+		{"mode is not client (3)", &packet{Settings: 0x1A}},                       // mode 2
+		{"version below minVersion", &packet{Settings: 0<<6 | 0<<3 | clientMode}}, // VN=0
+		{"version above maxVersion", &packet{Settings: 0<<6 | 7<<3 | clientMode}}, // VN=7
+		{"leap indicator is alarm condition", &packet{Settings: 3<<6 | 3<<3 | clientMode}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rsp := sendRequest(t, addr, tc.req); rsp != nil {
+				t.Errorf("got a reply to %+v, want none", tc.req)
+			}
+		})
+	}
+}
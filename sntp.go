@@ -1,83 +1,113 @@
-package main
+// Package sntp implements a minimal SNTP (Simple Network Time Protocol)
+// client as described in RFC 5905.
+package sntp
 
 import (
-	"encoding/binary"
-	"flag"
 	"fmt"
-	"log"
-	"net"
 	"time"
 )
 
-const ntpEpochOffset = 2208988800
-
-// NTP packet format (v3 with optional v4 fields removed)
-//
-// 0                   1                   2                   3
-// 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |LI | VN  |Mode |    Stratum     |     Poll      |  Precision   |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |                         Root Delay                            |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |                         Root Dispersion                       |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |                          Reference ID                         |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |                                                               |
-// +                     Reference Timestamp (64)                  +
-// |                                                               |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |                                                               |
-// +                      Origin Timestamp (64)                    +
-// |                                                               |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |                                                               |
-// +                      Receive Timestamp (64)                   +
-// |                                                               |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-// |                                                               |
-// +                      Transmit Timestamp (64)                  +
-// |                                                               |
-// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-//
-type packet struct {
-	Settings       uint8  // leap yr indicator, ver number, and mode
-	Stratum        uint8  // stratum of local clock
-	Poll           int8   // poll exponent
-	Precision      int8   // precision exponent
-	RootDelay      uint32 // root delay
-	RootDispersion uint32 // root dispersion
-	ReferenceID    uint32 // reference id
-	RefTimeSec     uint32 // reference timestamp sec
-	RefTimeFrac    uint32 // reference timestamp fractional
-	OrigTimeSec    uint32 // origin time secs
-	OrigTimeFrac   uint32 // origin time fractional
-	RxTimeSec      uint32 // receive time secs
-	RxTimeFrac     uint32 // receive time frac
-	TxTimeSec      uint32 // transmit time secs
-	TxTimeFrac     uint32 // transmit time frac
+// defaultTimeout bounds how long a single SNTP exchange may take.
+const defaultTimeout = 5 * time.Second
+
+// defaultNetwork is the network passed to NewTransport when Query is
+// not given an explicit Transport or network.
+const defaultNetwork = "udp"
+
+// maxPacketSize is large enough to hold the 48-byte header plus any
+// NTPv4 extension fields and MAC trailer a server might send, without
+// risking UDP fragmentation.
+const maxPacketSize = 1472
+
+// Response holds the result of a single SNTP exchange.
+type Response struct {
+	// Time is the server's transmit timestamp (T3).
+	Time time.Time
+	// ClockOffset is the estimated difference between the local clock
+	// and the server's clock: positive means the local clock is behind.
+	ClockOffset time.Duration
+	// RTT is the round-trip delay of the exchange, with the time spent
+	// processing on the server subtracted out.
+	RTT time.Duration
+
+	Stratum        uint8
+	LeapIndicator  uint8
+	ReferenceID    uint32
+	RootDelay      time.Duration
+	RootDispersion time.Duration
+	Precision      time.Duration
 }
 
-// This program implements a trivial NTP client over UDP.
-//
-// Usage:
-// time -e <host endpoint as addr:port>
-//
-func main() {
-	var host string
-	flag.StringVar(&host, "e", "us.pool.ntp.org:123", "NTP host")
-	flag.Parse()
-
-	// Setup a UDP connection
-	conn, err := net.Dial("udp", host)
-	if err != nil {
-		log.Fatal("failed to connect:", err)
+// config holds the resolved settings for a Query call.
+type config struct {
+	timeout        time.Duration
+	sampleInterval time.Duration
+
+	keyID   uint32
+	key     []byte
+	macAlgo MACAlgo
+
+	network   string
+	transport Transport
+}
+
+func defaultConfig() *config {
+	return &config{
+		timeout:        defaultTimeout,
+		sampleInterval: defaultSampleInterval,
+		network:        defaultNetwork,
 	}
-	defer conn.Close()
-	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
-		log.Fatal("failed to set deadline: ", err)
+}
+
+// Option customizes a Query or QueryWithSamples call.
+type Option func(*config)
+
+// WithTimeout overrides the deadline for a single SNTP exchange.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// WithNetwork pins the address family used to resolve host, and must be
+// one of "udp", "udp4", or "udp6". It has no effect if WithTransport is
+// also given. Defaults to "udp".
+func WithNetwork(network string) Option {
+	return func(c *config) {
+		c.network = network
 	}
+}
+
+// WithTransport uses an existing Transport instead of dialing a new one
+// for this call, so a single socket can be reused across many Query
+// calls. The caller remains responsible for closing it.
+func WithTransport(t Transport) Option {
+	return func(c *config) {
+		c.transport = t
+	}
+}
+
+// Query performs a single SNTP exchange against host and returns the
+// server's response along with the computed clock offset and round-trip
+// delay. host may be a bare hostname, in which case the standard NTP
+// port 123 is assumed. By default a new UDP socket is dialed and closed
+// for this call alone; pass WithTransport to reuse an existing one.
+func Query(host string, opts ...Option) (*Response, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	transport := cfg.transport
+	if transport == nil {
+		t, err := NewTransport(cfg.network, host)
+		if err != nil {
+			return nil, err
+		}
+		defer t.Close()
+		transport = t
+	}
+
 	// configure request settings by specifying the first byte as
 	// 00 011 011 (or 0x1B)
 	// |  |   +-- client mode (3)
@@ -85,42 +115,62 @@ func main() {
 	// + -------- leap year indicator, 0 no warning
 	req := &packet{Settings: 0x1B}
 
-	// send time request
-	fmt.Println(time.Now())
-	t1 := time.Now()
-	if err := binary.Write(conn, binary.BigEndian, req); err != nil {
-		log.Fatalf("failed to send request: %v", err)
+	// Randomize the transmit timestamp we send and require the server
+	// to echo it back in the response's origin timestamp, so a spoofed
+	// or replayed response can be detected.
+	txSec, txFrac, err := randomTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("sntp: failed to generate request timestamp: %w", err)
 	}
+	req.TxTimeSec, req.TxTimeFrac = txSec, txFrac
 
-	// block to receive server response
-	rsp := &packet{}
-	if err := binary.Read(conn, binary.BigEndian, rsp); err != nil {
-		log.Fatalf("failed to read server response: %v", err)
+	reqBytes, err := marshalPacket(req)
+	if err != nil {
+		return nil, fmt.Errorf("sntp: failed to encode request: %w", err)
+	}
+	if cfg.key != nil {
+		reqBytes, err = appendMAC(reqBytes, cfg.keyID, cfg.key, cfg.macAlgo)
+		if err != nil {
+			return nil, fmt.Errorf("sntp: failed to authenticate request: %w", err)
+		}
 	}
-	t4 := time.Now()
 
-	// On POSIX-compliant OS, time is expressed
-	// using the Unix time epoch (or secs since year 1970).
-	// NTP seconds are counted since 1900 and therefore must
-	// be corrected with an epoch offset to convert NTP seconds
-	// to Unix time by removing 70 yrs of seconds (1970-1900)
-	// or 2208988800 seconds.
-	//fmt.Println(rsp)
+	t1 := time.Now()
+	rspBytes, t4, err := transport.Exchange(reqBytes, t1.Add(cfg.timeout))
+	if err != nil {
+		return nil, fmt.Errorf("sntp: exchange failed: %w", err)
+	}
+	if len(rspBytes) < packetSize {
+		return nil, fmt.Errorf("sntp: short response (%d bytes)", len(rspBytes))
+	}
 
-	printTime(rsp.OrigTimeSec, rsp.OrigTimeFrac)
-	printTime(rsp.RefTimeSec, rsp.RefTimeFrac)
-	t2 := printTime(rsp.RxTimeSec, rsp.RxTimeFrac)
-	t3 := printTime(rsp.TxTimeSec, rsp.TxTimeFrac)
+	rsp, err := unmarshalPacket(rspBytes)
+	if err != nil {
+		return nil, fmt.Errorf("sntp: failed to decode server response: %w", err)
+	}
 
-	fmt.Println(t2.Sub(t1))
-	fmt.Println(t3.Sub(t4))
+	if cfg.key != nil {
+		if err := verifyMAC(rspBytes, cfg.keyID, cfg.key, cfg.macAlgo); err != nil {
+			return nil, err
+		}
+	}
 
+	if err := validateResponse(req, rsp); err != nil {
+		return nil, err
+	}
 
-}
+	t2 := ntpToTime(rsp.RxTimeSec, rsp.RxTimeFrac)
+	t3 := ntpToTime(rsp.TxTimeSec, rsp.TxTimeFrac)
 
-func printTime(s, f uint32) time.Time {
-	secs := float64(s) - ntpEpochOffset
-	nanos := (int64(f) * 1e9) >> 32 // convert fractional to nanos
-	fmt.Printf("%v\n", time.Unix(int64(secs), nanos))
-	return time.Unix(int64(secs), nanos)
+	return &Response{
+		Time:           t3,
+		ClockOffset:    ((t2.Sub(t1)) + (t3.Sub(t4))) / 2,
+		RTT:            (t4.Sub(t1)) - (t3.Sub(t2)),
+		Stratum:        rsp.Stratum,
+		LeapIndicator:  rsp.leapIndicator(),
+		ReferenceID:    rsp.ReferenceID,
+		RootDelay:      shortToDuration(rsp.RootDelay),
+		RootDispersion: shortToDuration(rsp.RootDispersion),
+		Precision:      precisionToDuration(rsp.Precision),
+	}, nil
 }
@@ -0,0 +1,176 @@
+package sntp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// Transport abstracts the UDP socket used for an SNTP exchange. The
+// default implementation, returned by NewTransport, wraps a single UDP
+// socket that can be reused across many Query calls; callers that need
+// to bind a specific local address or interface, or otherwise control
+// the underlying connection, can provide their own.
+type Transport interface {
+	// Exchange sends req and returns the first response received
+	// before deadline, along with the time it arrived. Implementations
+	// should prefer a kernel receive timestamp over time.Now() when the
+	// platform supports it.
+	Exchange(req []byte, deadline time.Time) (resp []byte, rxTime time.Time, err error)
+	// Close releases any resources owned by the transport.
+	Close() error
+}
+
+// udpTransport is the default Transport, backed by a single connected
+// UDP socket.
+type udpTransport struct {
+	conn *net.UDPConn
+	mu   sync.Mutex // serializes Exchange so a reused socket isn't shared mid-exchange
+
+	// prevOrig is the nonce echoed by the previous Exchange's request,
+	// used to recognize and discard a straggler reply to it.
+	prevOrig []byte
+}
+
+// transportConfig holds the resolved settings for NewTransport.
+type transportConfig struct {
+	localAddr *net.UDPAddr
+	ttl       int
+}
+
+// TransportOption customizes NewTransport.
+type TransportOption func(*transportConfig)
+
+// WithLocalAddr binds the transport's socket to a specific local
+// address or interface, e.g. to pin the source address used for
+// outgoing requests.
+func WithLocalAddr(addr *net.UDPAddr) TransportOption {
+	return func(c *transportConfig) {
+		c.localAddr = addr
+	}
+}
+
+// WithTTL sets the IP TTL (hop limit for IPv6) used for outgoing
+// packets. Left unset, the system default is used.
+func WithTTL(ttl int) TransportOption {
+	return func(c *transportConfig) {
+		c.ttl = ttl
+	}
+}
+
+// NewTransport dials a UDP socket to host over network, which must be
+// one of "udp", "udp4", or "udp6" so callers can pin an address family.
+// A bare hostname with no port is assumed to use the standard NTP port
+// 123. The returned Transport may be reused across many Query calls and
+// must be Closed when no longer needed.
+func NewTransport(network, host string, opts ...TransportOption) (Transport, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+	default:
+		return nil, fmt.Errorf("sntp: unsupported network %q", network)
+	}
+
+	cfg := &transportConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	raddr, err := net.ResolveUDPAddr(network, ensurePort(host))
+	if err != nil {
+		return nil, fmt.Errorf("sntp: failed to resolve %q: %w", host, err)
+	}
+
+	conn, err := net.DialUDP(network, cfg.localAddr, raddr)
+	if err != nil {
+		return nil, fmt.Errorf("sntp: failed to dial %q: %w", host, err)
+	}
+
+	if err := setTTL(conn, raddr, cfg.ttl); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sntp: failed to set TTL: %w", err)
+	}
+
+	// Best effort: not all platforms support kernel receive timestamps,
+	// in which case Exchange falls back to time.Now().
+	_ = enableKernelTimestamps(conn)
+
+	return &udpTransport{conn: conn}, nil
+}
+
+// setTTL sets the outgoing TTL (or IPv6 hop limit) on conn, if ttl > 0.
+func setTTL(conn *net.UDPConn, raddr *net.UDPAddr, ttl int) error {
+	if ttl <= 0 {
+		return nil
+	}
+	if raddr.IP.To4() != nil {
+		return ipv4.NewConn(conn).SetTTL(ttl)
+	}
+	return ipv6.NewConn(conn).SetHopLimit(ttl)
+}
+
+// ensurePort appends the standard NTP port to host if it doesn't already
+// specify one.
+func ensurePort(host string) string {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		return net.JoinHostPort(host, "123")
+	}
+	return host
+}
+
+// origTimeOffset locates the OrigTimestamp field within a response
+// header, and txTimeOffset the TxTimestamp field within a request
+// header; a genuine reply echoes the latter back as the former.
+const (
+	origTimeOffset = 24
+	txTimeOffset   = 40
+	timestampLen   = 8
+)
+
+func (t *udpTransport) Exchange(req []byte, deadline time.Time) ([]byte, time.Time, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.conn.SetDeadline(deadline); err != nil {
+		return nil, time.Time{}, err
+	}
+	if _, err := t.conn.Write(req); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// On a reused socket, a straggler reply to the *previous* Exchange
+	// may still arrive after this one's request was sent. Recognize it
+	// by the nonce that call echoed and discard it, so it can't be
+	// misattributed to this exchange; anything else - including a
+	// response that simply fails to echo our nonce - is handed straight
+	// to the caller for protocol-level validation rather than silently
+	// retried.
+	staleOrig := t.prevOrig
+	if len(req) >= txTimeOffset+timestampLen {
+		t.prevOrig = append([]byte(nil), req[txTimeOffset:txTimeOffset+timestampLen]...)
+	} else {
+		t.prevOrig = nil
+	}
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, rxTime, err := readWithKernelTimestamp(t.conn, buf)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if staleOrig != nil && n >= origTimeOffset+timestampLen && bytes.Equal(buf[origTimeOffset:origTimeOffset+timestampLen], staleOrig) {
+			continue
+		}
+		resp := make([]byte, n)
+		copy(resp, buf[:n])
+		return resp, rxTime, nil
+	}
+}
+
+func (t *udpTransport) Close() error {
+	return t.conn.Close()
+}
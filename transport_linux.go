@@ -0,0 +1,76 @@
+//go:build linux
+
+package sntp
+
+import (
+	"net"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// enableKernelTimestamps asks the kernel to attach a SO_TIMESTAMPNS
+// control message, giving the time a packet arrived at the NIC driver
+// rather than when userspace got around to reading it.
+func enableKernelTimestamps(conn *net.UDPConn) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	if err := rc.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_TIMESTAMPNS, 1)
+	}); err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// readWithKernelTimestamp reads one packet from conn into buf, returning
+// the kernel's SO_TIMESTAMPNS receive time when available, falling back
+// to time.Now() otherwise.
+func readWithKernelTimestamp(conn *net.UDPConn, buf []byte) (int, time.Time, error) {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	oob := make([]byte, unix.CmsgSpace(int(unsafe.Sizeof(unix.Timespec{}))))
+	var n, oobn int
+	var readErr error
+	if ctrlErr := rc.Read(func(fd uintptr) bool {
+		n, oobn, _, _, readErr = unix.Recvmsg(int(fd), buf, oob, 0)
+		return readErr != unix.EAGAIN
+	}); ctrlErr != nil {
+		return 0, time.Time{}, ctrlErr
+	}
+	if readErr != nil {
+		return 0, time.Time{}, readErr
+	}
+
+	if rxTime, ok := parseKernelTimestamp(oob[:oobn]); ok {
+		return n, rxTime, nil
+	}
+	return n, time.Now(), nil
+}
+
+// parseKernelTimestamp extracts a SO_TIMESTAMPNS control message from
+// the ancillary data returned alongside a packet.
+func parseKernelTimestamp(oob []byte) (time.Time, bool) {
+	cmsgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, c := range cmsgs {
+		if c.Header.Level != unix.SOL_SOCKET || c.Header.Type != unix.SO_TIMESTAMPNS {
+			continue
+		}
+		if len(c.Data) < int(unsafe.Sizeof(unix.Timespec{})) {
+			continue
+		}
+		ts := *(*unix.Timespec)(unsafe.Pointer(&c.Data[0]))
+		return time.Unix(int64(ts.Sec), int64(ts.Nsec)), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sntp
+
+import (
+	"net"
+	"time"
+)
+
+// enableKernelTimestamps is a no-op on platforms without SO_TIMESTAMPNS
+// support; readWithKernelTimestamp falls back to time.Now().
+func enableKernelTimestamps(conn *net.UDPConn) error {
+	return nil
+}
+
+func readWithKernelTimestamp(conn *net.UDPConn, buf []byte) (int, time.Time, error) {
+	n, err := conn.Read(buf)
+	return n, time.Now(), err
+}
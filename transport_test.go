@@ -0,0 +1,136 @@
+package sntp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewTransportRejectsUnsupportedNetwork(t *testing.T) {
+	if _, err := NewTransport("tcp", "127.0.0.1:123"); err == nil {
+		t.Fatal("NewTransport(\"tcp\", ...) = nil error, want error")
+	}
+}
+
+func TestEnsurePort(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"time.example.com", "time.example.com:123"},
+		{"time.example.com:1234", "time.example.com:1234"},
+		{"127.0.0.1", "127.0.0.1:123"},
+	}
+	for _, tc := range cases {
+		if got := ensurePort(tc.in); got != tc.want {
+			t.Errorf("ensurePort(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// fakeReply builds a server-mode response echoing reqBytes' transmit
+// timestamp as its origin timestamp, as a genuine reply would.
+func fakeReply(t *testing.T, reqBytes []byte) []byte {
+	t.Helper()
+	req, err := unmarshalPacket(reqBytes)
+	if err != nil {
+		t.Fatalf("unmarshalPacket: %v", err)
+	}
+	rsp := &packet{
+		Settings:     0<<6 | req.version()<<3 | serverMode,
+		Stratum:      1,
+		OrigTimeSec:  req.TxTimeSec,
+		OrigTimeFrac: req.TxTimeFrac,
+		TxTimeSec:    1000,
+		TxTimeFrac:   1,
+	}
+	b, err := marshalPacket(rsp)
+	if err != nil {
+		t.Fatalf("marshalPacket: %v", err)
+	}
+	return b
+}
+
+// TestUDPTransportDiscardsStragglerReply reproduces a socket reused
+// across Query calls: the first exchange times out waiting on a slow
+// server, whose reply only lands after the deadline. That straggler
+// must never be handed back as the result of the *next* Exchange on the
+// same Transport.
+func TestUDPTransportDiscardsStragglerReply(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	defer server.Close()
+
+	type captured struct {
+		bytes []byte
+		addr  net.Addr
+	}
+	reqCh := make(chan captured, 2)
+	go func() {
+		buf := make([]byte, maxPacketSize)
+		for i := 0; i < 2; i++ {
+			n, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			b := make([]byte, n)
+			copy(b, buf[:n])
+			reqCh <- captured{b, addr}
+		}
+	}()
+
+	transport, err := NewTransport("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	defer transport.Close()
+
+	req1, err := marshalPacket(&packet{Settings: 0x1B, TxTimeSec: 111, TxTimeFrac: 222})
+	if err != nil {
+		t.Fatalf("marshalPacket: %v", err)
+	}
+	if _, _, err := transport.Exchange(req1, time.Now().Add(50*time.Millisecond)); err == nil {
+		t.Fatal("Exchange(req1) = nil error, want timeout (server hasn't replied yet)")
+	}
+
+	cap1 := <-reqCh
+	if _, err := server.WriteTo(fakeReply(t, cap1.bytes), cap1.addr); err != nil {
+		t.Fatalf("WriteTo (straggler reply): %v", err)
+	}
+	// Give the straggler reply time to land in the client socket before
+	// the next Exchange starts reading from it.
+	time.Sleep(50 * time.Millisecond)
+
+	req2, err := marshalPacket(&packet{Settings: 0x1B, TxTimeSec: 333, TxTimeFrac: 444})
+	if err != nil {
+		t.Fatalf("marshalPacket: %v", err)
+	}
+
+	type result struct {
+		resp []byte
+		err  error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		resp, _, err := transport.Exchange(req2, time.Now().Add(2*time.Second))
+		resultCh <- result{resp, err}
+	}()
+
+	cap2 := <-reqCh
+	if _, err := server.WriteTo(fakeReply(t, cap2.bytes), cap2.addr); err != nil {
+		t.Fatalf("WriteTo (genuine reply): %v", err)
+	}
+
+	res := <-resultCh
+	if res.err != nil {
+		t.Fatalf("Exchange(req2) = %v, want nil", res.err)
+	}
+	rsp, err := unmarshalPacket(res.resp)
+	if err != nil {
+		t.Fatalf("unmarshalPacket(Exchange(req2) result): %v", err)
+	}
+	if rsp.OrigTimeSec != 333 || rsp.OrigTimeFrac != 444 {
+		t.Errorf("Exchange(req2) returned OrigTimestamp (%d, %d), want (333, 444); the straggler reply to req1 leaked through", rsp.OrigTimeSec, rsp.OrigTimeFrac)
+	}
+}
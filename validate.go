@@ -0,0 +1,52 @@
+package sntp
+
+import "crypto/rand"
+
+// serverMode is the Mode value a correctly-behaving SNTP server must
+// use in its response.
+const serverMode = 4
+
+// randomTimestamp generates a randomized 64-bit NTP timestamp, using
+// crypto/rand, to place in the request's transmit timestamp. The server
+// is required to echo it back in the response's origin timestamp, which
+// lets Query detect spoofed or replayed responses.
+func randomTimestamp() (sec, frac uint32, err error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, 0, err
+	}
+	sec = uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	frac = uint32(b[4])<<24 | uint32(b[5])<<16 | uint32(b[6])<<8 | uint32(b[7])
+	return sec, frac, nil
+}
+
+// validateResponse checks rsp against req and the requirements of
+// RFC 5905 before its timestamps may be trusted.
+func validateResponse(req, rsp *packet) error {
+	// Confirm this is actually a reply to our request before trusting
+	// anything else it says: an attacker who can't see the nonce we
+	// sent can still forge a plausible-looking Stratum, LeapIndicator,
+	// Mode, or version, so those checks must not run first.
+	if rsp.OrigTimeSec != req.TxTimeSec || rsp.OrigTimeFrac != req.TxTimeFrac {
+		return ErrSpoofedResponse
+	}
+	if rsp.Stratum == 0 {
+		return &KissOfDeathError{Code: decodeKissCode(rsp.ReferenceID)}
+	}
+	if rsp.Stratum >= 16 {
+		return ErrInvalidStratum
+	}
+	if rsp.mode() != serverMode {
+		return ErrInvalidMode
+	}
+	if rsp.version() != req.version() {
+		return ErrVersionMismatch
+	}
+	if rsp.leapIndicator() == 3 {
+		return ErrNotSynchronized
+	}
+	if rsp.TxTimeSec == 0 && rsp.TxTimeFrac == 0 {
+		return ErrZeroTransmitTimestamp
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package sntp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeKissCode(t *testing.T) {
+	cases := []struct {
+		ascii string
+		want  KissCode
+	}{
+		{"RATE", "RATE"},
+		{"DENY", "DENY"},
+		{"RSTR", "RSTR"},
+	}
+	for _, tc := range cases {
+		id := referenceIDFromASCII(tc.ascii)
+		if got := decodeKissCode(id); got != tc.want {
+			t.Errorf("decodeKissCode(referenceIDFromASCII(%q)) = %q, want %q", tc.ascii, got, tc.want)
+		}
+	}
+}
+
+func validReqResp() (req, rsp *packet) {
+	req = &packet{Settings: 0x1B, TxTimeSec: 42, TxTimeFrac: 99}
+	rsp = &packet{
+		Settings:     0<<6 | 3<<3 | serverMode,
+		Stratum:      1,
+		OrigTimeSec:  req.TxTimeSec,
+		OrigTimeFrac: req.TxTimeFrac,
+		TxTimeSec:    1000,
+		TxTimeFrac:   1,
+	}
+	return req, rsp
+}
+
+func TestValidateResponse(t *testing.T) {
+	t.Run("valid response passes", func(t *testing.T) {
+		req, rsp := validReqResp()
+		if err := validateResponse(req, rsp); err != nil {
+			t.Fatalf("validateResponse() = %v, want nil", err)
+		}
+	})
+
+	t.Run("spoofed response is rejected before any other field is trusted", func(t *testing.T) {
+		req, rsp := validReqResp()
+		rsp.OrigTimeSec++ // doesn't echo our nonce
+		rsp.Stratum = 0   // would otherwise look like a kiss-of-death
+		rsp.ReferenceID = referenceIDFromASCII("RATE")
+
+		err := validateResponse(req, rsp)
+		if !errors.Is(err, ErrSpoofedResponse) {
+			t.Fatalf("validateResponse() = %v, want ErrSpoofedResponse (forged Stratum must not be trusted first)", err)
+		}
+	})
+
+	t.Run("kiss of death", func(t *testing.T) {
+		req, rsp := validReqResp()
+		rsp.Stratum = 0
+		rsp.ReferenceID = referenceIDFromASCII("RATE")
+
+		err := validateResponse(req, rsp)
+		var kod *KissOfDeathError
+		if !errors.As(err, &kod) {
+			t.Fatalf("validateResponse() = %v, want *KissOfDeathError", err)
+		}
+		if kod.Code != "RATE" {
+			t.Errorf("kod.Code = %q, want RATE", kod.Code)
+		}
+		if !errors.Is(err, ErrKissOfDeath) {
+			t.Errorf("errors.Is(err, ErrKissOfDeath) = false, want true")
+		}
+	})
+
+	t.Run("invalid stratum", func(t *testing.T) {
+		req, rsp := validReqResp()
+		rsp.Stratum = 16
+		if err := validateResponse(req, rsp); !errors.Is(err, ErrInvalidStratum) {
+			t.Fatalf("validateResponse() = %v, want ErrInvalidStratum", err)
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		req, rsp := validReqResp()
+		rsp.Settings = 0<<6 | 3<<3 | 3 // mode 3 (client), not 4 (server)
+		if err := validateResponse(req, rsp); !errors.Is(err, ErrInvalidMode) {
+			t.Fatalf("validateResponse() = %v, want ErrInvalidMode", err)
+		}
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		req, rsp := validReqResp()
+		rsp.Settings = 0<<6 | 4<<3 | serverMode // VN=4, req was VN=3
+		if err := validateResponse(req, rsp); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("validateResponse() = %v, want ErrVersionMismatch", err)
+		}
+	})
+
+	t.Run("not synchronized", func(t *testing.T) {
+		req, rsp := validReqResp()
+		rsp.Settings = 3<<6 | 3<<3 | serverMode // LI=3
+		if err := validateResponse(req, rsp); !errors.Is(err, ErrNotSynchronized) {
+			t.Fatalf("validateResponse() = %v, want ErrNotSynchronized", err)
+		}
+	})
+
+	t.Run("zero transmit timestamp", func(t *testing.T) {
+		req, rsp := validReqResp()
+		rsp.TxTimeSec, rsp.TxTimeFrac = 0, 0
+		if err := validateResponse(req, rsp); !errors.Is(err, ErrZeroTransmitTimestamp) {
+			t.Fatalf("validateResponse() = %v, want ErrZeroTransmitTimestamp", err)
+		}
+	})
+}
+
+func TestRandomTimestampVaries(t *testing.T) {
+	sec1, frac1, err := randomTimestamp()
+	if err != nil {
+		t.Fatalf("randomTimestamp: %v", err)
+	}
+	sec2, frac2, err := randomTimestamp()
+	if err != nil {
+		t.Fatalf("randomTimestamp: %v", err)
+	}
+	if sec1 == sec2 && frac1 == frac2 {
+		t.Errorf("randomTimestamp returned the same value twice in a row: (%d, %d)", sec1, frac1)
+	}
+}